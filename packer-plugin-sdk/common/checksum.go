@@ -0,0 +1,100 @@
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// isChecksumReference reports whether checksum points at a file
+// containing the real checksum rather than being the checksum itself,
+// i.e. a "file:" URL, a bare path, or an http(s) URL.
+func isChecksumReference(checksum string) bool {
+	if checksum == "" {
+		return false
+	}
+
+	if strings.HasPrefix(checksum, "file:") {
+		return true
+	}
+
+	if u, err := url.Parse(checksum); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		return true
+	}
+
+	// Anything left that isn't a bare hex digest must be a path: real
+	// checksums are only ever hex-encoded digests.
+	return !isHexString(checksum)
+}
+
+// isHexString reports whether s consists entirely of hexadecimal
+// digits, as a checksum value would.
+func isHexString(s string) bool {
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+		case r >= 'a' && r <= 'f':
+		case r >= 'A' && r <= 'F':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// fetchChecksumFile retrieves the contents of a checksum manifest
+// referenced as a "file:" URL, a bare local path, or an http(s) URL.
+func fetchChecksumFile(ref string) ([]byte, error) {
+	if path := strings.TrimPrefix(ref, "file:"); path != ref {
+		return ioutil.ReadFile(path)
+	}
+
+	u, err := url.Parse(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		resp, err := http.Get(ref)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to fetch checksum file %s: %s", ref, resp.Status)
+		}
+
+		return ioutil.ReadAll(resp.Body)
+	default:
+		return ioutil.ReadFile(ref)
+	}
+}
+
+// checksumFromManifest scans a coreutils `sha*sum`/`md5sum`-style
+// manifest (lines of "<hex>  <filename>", optionally "*filename" to
+// mark binary mode) for the line matching name and returns its hex
+// digest.
+func checksumFromManifest(manifest []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+
+		if strings.TrimPrefix(fields[1], "*") == name {
+			return fields[0], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	return "", fmt.Errorf("no checksum found for %q in manifest", name)
+}
@@ -0,0 +1,88 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestChecksumFromManifest(t *testing.T) {
+	manifest := []byte(
+		"d41d8cd98f00b204e9800998ecf8427e  empty.iso\n" +
+			"\n" +
+			"not a valid line\n" +
+			"098f6bcd4621d373cade4e832627b4f6 *binary.iso\n",
+	)
+
+	cases := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "empty.iso", want: "d41d8cd98f00b204e9800998ecf8427e"},
+		{name: "binary.iso", want: "098f6bcd4621d373cade4e832627b4f6"},
+		{name: "missing.iso", wantErr: true},
+	}
+
+	for _, c := range cases {
+		got, err := checksumFromManifest(manifest, c.name)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("checksumFromManifest(%q): expected error, got %q", c.name, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("checksumFromManifest(%q): unexpected error: %s", c.name, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("checksumFromManifest(%q) = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestIsChecksumReference(t *testing.T) {
+	cases := []struct {
+		checksum string
+		want     bool
+	}{
+		{"file:./SHA256SUMS", true},
+		{"http://example.com/SHA256SUMS", true},
+		{"https://example.com/SHA256SUMS", true},
+		{"./SHA256SUMS", true},
+		{"d41d8cd98f00b204e9800998ecf8427e", false},
+		{"", false},
+	}
+
+	for _, c := range cases {
+		if got := isChecksumReference(c.checksum); got != c.want {
+			t.Errorf("isChecksumReference(%q) = %v, want %v", c.checksum, got, c.want)
+		}
+	}
+}
+
+func TestFetchChecksumFileLocal(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "SHA256SUMS")
+	want := "deadbeef  some.iso\n"
+	if err := os.WriteFile(path, []byte(want), 0o644); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+
+	got, err := fetchChecksumFile("file:" + path)
+	if err != nil {
+		t.Fatalf("fetchChecksumFile(file:): unexpected error: %s", err)
+	}
+	if string(got) != want {
+		t.Errorf("fetchChecksumFile(file:) = %q, want %q", got, want)
+	}
+
+	got, err = fetchChecksumFile(path)
+	if err != nil {
+		t.Fatalf("fetchChecksumFile(bare path): unexpected error: %s", err)
+	}
+	if string(got) != want {
+		t.Errorf("fetchChecksumFile(bare path) = %q, want %q", got, want)
+	}
+}
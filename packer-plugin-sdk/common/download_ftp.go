@@ -0,0 +1,76 @@
+package common
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+
+	"github.com/jlaffaye/ftp"
+	"gopkg.in/cheggaaa/pb.v1"
+)
+
+// FTPDownloader implements Downloader for the "ftp" scheme. It exists
+// mainly so that builds on air-gapped networks can pull ISOs from an
+// internal FTP mirror instead of a pre-staged local copy.
+type FTPDownloader struct{}
+
+func (d *FTPDownloader) Download(ctx context.Context, dst *os.File, src *url.URL, config *DownloadConfig, bar *pb.ProgressBar) error {
+	// FTP resuming isn't implemented, so unconditionally truncate per
+	// the contract documented on truncate().
+	if err := truncate(dst); err != nil {
+		return err
+	}
+
+	host := src.Host
+	if src.Port() == "" {
+		host += ":21"
+	}
+
+	conn, err := ftp.Dial(host, ftp.DialWithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer conn.Quit()
+
+	user := "anonymous"
+	pass := "anonymous"
+	if src.User != nil {
+		user = src.User.Username()
+		if p, ok := src.User.Password(); ok {
+			pass = p
+		}
+	}
+
+	if err := conn.Login(user, pass); err != nil {
+		return err
+	}
+
+	r, err := conn.Retr(src.Path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	// jlaffaye/ftp's Retr response has no context support of its own,
+	// so the best we can do for an in-flight transfer is force-close
+	// its data connection as soon as ctx is done.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			r.Close()
+		case <-done:
+		}
+	}()
+
+	var body io.Reader = r
+	if bar != nil {
+		bar.Start()
+		body = bar.NewProxyReader(r)
+	}
+
+	_, err = io.Copy(dst, body)
+	return err
+}
@@ -0,0 +1,107 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"gopkg.in/cheggaaa/pb.v1"
+)
+
+// HTTPDownloader implements Downloader for the "http" and "https"
+// schemes.
+type HTTPDownloader struct{}
+
+func (d *HTTPDownloader) Download(ctx context.Context, dst *os.File, src *url.URL, config *DownloadConfig, bar *pb.ProgressBar) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", src.String(), nil)
+	if err != nil {
+		return err
+	}
+	if config.UserAgent != "" {
+		req.Header.Set("User-Agent", config.UserAgent)
+	}
+
+	fi, err := dst.Stat()
+	if err != nil {
+		return err
+	}
+
+	var offset int64
+	if config.Resume {
+		offset = fi.Size()
+		if offset > 0 {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		}
+	} else if fi.Size() > 0 {
+		// Not resuming, but the .part file already has bytes on disk
+		// from a previous attempt (possibly a different URL in
+		// StepDownload's fallback list) -- start clean rather than
+		// let io.Copy overwrite only the leading bytes and leave
+		// stale trailing data behind.
+		if err := truncate(dst); err != nil {
+			return err
+		}
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		// The server honored our Range request. Make sure it's
+		// actually resuming from the offset we asked for before we
+		// trust the bytes already on disk; if not, start clean.
+		if !strings.HasPrefix(resp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", offset)) {
+			if err := truncate(dst); err != nil {
+				return err
+			}
+			offset = 0
+		} else if _, err := dst.Seek(0, io.SeekEnd); err != nil {
+			return err
+		}
+	case http.StatusOK:
+		// No range support, or we didn't ask for one. Either way the
+		// server is about to send the whole file, so drop whatever we
+		// had on disk.
+		if offset > 0 {
+			if err := truncate(dst); err != nil {
+				return err
+			}
+			offset = 0
+		}
+	default:
+		return fmt.Errorf("unexpected HTTP response downloading %s: %s", src, resp.Status)
+	}
+
+	var body io.Reader = resp.Body
+	if bar != nil {
+		bar.Total = offset + resp.ContentLength
+		bar.Set64(offset)
+		bar.Start()
+		body = bar.NewProxyReader(resp.Body)
+	}
+
+	_, err = io.Copy(dst, body)
+	return err
+}
+
+// truncate discards any bytes already written to f and rewinds it. Every
+// Downloader must call this before writing unless it is actually
+// resuming from a validated offset -- the .part file is deliberately
+// left on disk after a failed attempt (see download.go), and
+// StepDownload may hand that same file to a different URL or a
+// non-resuming downloader on the next try.
+func truncate(f *os.File) error {
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	_, err := f.Seek(0, io.SeekStart)
+	return err
+}
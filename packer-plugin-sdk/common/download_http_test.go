@@ -0,0 +1,151 @@
+package common
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newPartFile creates a .part file seeded with stale bytes, simulating
+// what's left behind by a prior failed/interrupted attempt.
+func newPartFile(t *testing.T, stale string) *os.File {
+	t.Helper()
+	f, err := os.Create(filepath.Join(t.TempDir(), "download.part"))
+	if err != nil {
+		t.Fatalf("Create: %s", err)
+	}
+	if stale != "" {
+		if _, err := f.WriteString(stale); err != nil {
+			t.Fatalf("WriteString: %s", err)
+		}
+	}
+	return f
+}
+
+func readAll(t *testing.T, f *os.File) string {
+	t.Helper()
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("Seek: %s", err)
+	}
+	b, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("ReadAll: %s", err)
+	}
+	return string(b)
+}
+
+// TestHTTPDownloaderTruncateMatrix exercises the resume/truncate
+// decision matrix in HTTPDownloader.Download: whether stale bytes
+// already on disk are kept or discarded depends on config.Resume and
+// what the server actually responds with, not on Resume alone.
+func TestHTTPDownloaderTruncateMatrix(t *testing.T) {
+	const full = "0123456789"
+
+	cases := []struct {
+		name   string
+		resume bool
+		stale  string
+		server func(w http.ResponseWriter, r *http.Request)
+		want   string
+	}{
+		{
+			name:   "no resume, no stale bytes",
+			resume: false,
+			stale:  "",
+			server: func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, full)
+			},
+			want: full,
+		},
+		{
+			name:   "no resume, stale bytes from a previous attempt are discarded",
+			resume: false,
+			stale:  "XXXXXXXXXXXXXX",
+			server: func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, full)
+			},
+			want: full,
+		},
+		{
+			name:   "resume requested, server honors Range",
+			resume: true,
+			stale:  full[:5],
+			server: func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Range") != "bytes=5-" {
+					t.Errorf("unexpected Range header: %q", r.Header.Get("Range"))
+				}
+				w.Header().Set("Content-Range", "bytes 5-9/10")
+				w.WriteHeader(http.StatusPartialContent)
+				io.WriteString(w, full[5:])
+			},
+			want: full,
+		},
+		{
+			name:   "resume requested, server's Content-Range doesn't match our offset",
+			resume: true,
+			stale:  full[:5],
+			server: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Range", "bytes 0-9/10")
+				w.WriteHeader(http.StatusPartialContent)
+				io.WriteString(w, full)
+			},
+			want: full,
+		},
+		{
+			name:   "resume requested, server ignores Range and sends 200",
+			resume: true,
+			stale:  full[:5],
+			server: func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, full)
+			},
+			want: full,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(c.server))
+			defer srv.Close()
+
+			dst := newPartFile(t, c.stale)
+			defer dst.Close()
+
+			u, err := url.Parse(srv.URL)
+			if err != nil {
+				t.Fatalf("url.Parse: %s", err)
+			}
+
+			d := new(HTTPDownloader)
+			err = d.Download(context.Background(), dst, u, &DownloadConfig{Resume: c.resume}, nil)
+			if err != nil {
+				t.Fatalf("Download: %s", err)
+			}
+
+			if got := readAll(t, dst); got != c.want {
+				t.Errorf("downloaded content = %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestCacheFilename(t *testing.T) {
+	sum := sha1.Sum([]byte("http://example.com/some.iso"))
+	hash := hex.EncodeToString(sum[:])
+
+	got := cacheFilename("http://example.com/some.iso", "")
+	if got != hash {
+		t.Errorf("cacheFilename(url, \"\") = %q, want %q", got, hash)
+	}
+
+	got = cacheFilename("http://example.com/some.iso", "iso")
+	if want := hash + ".iso"; got != want {
+		t.Errorf("cacheFilename(url, \"iso\") = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,35 @@
+package common
+
+import "testing"
+
+func TestSplitSMBPath(t *testing.T) {
+	cases := []struct {
+		path         string
+		wantShare    string
+		wantFilePath string
+		wantErr      bool
+	}{
+		{path: "/share/path/to/file.iso", wantShare: "share", wantFilePath: `path\to\file.iso`},
+		{path: "/share/file.iso", wantShare: "share", wantFilePath: "file.iso"},
+		{path: "/share", wantErr: true},
+		{path: "/", wantErr: true},
+		{path: "", wantErr: true},
+	}
+
+	for _, c := range cases {
+		share, filePath, err := splitSMBPath(c.path)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("splitSMBPath(%q): expected error, got share=%q filePath=%q", c.path, share, filePath)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("splitSMBPath(%q): unexpected error: %s", c.path, err)
+			continue
+		}
+		if share != c.wantShare || filePath != c.wantFilePath {
+			t.Errorf("splitSMBPath(%q) = (%q, %q), want (%q, %q)", c.path, share, filePath, c.wantShare, c.wantFilePath)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/mitchellh/multistep"
+)
+
+// fakeUi is a packer.Ui that discards everything; the PreDownloadCheck
+// test only cares about the state bag and the returned StepAction.
+type fakeUi struct{}
+
+func (fakeUi) Ask(string) (string, error) { return "", nil }
+func (fakeUi) Say(string)                 {}
+func (fakeUi) Message(string)             {}
+func (fakeUi) Error(string)               {}
+func (fakeUi) Machine(string, ...string)  {}
+
+// fakeCache is a packer.Cache that should never be consulted once
+// PreDownloadCheck reports the file as already present.
+type fakeCache struct {
+	t *testing.T
+}
+
+func (c fakeCache) Lock(key string) string {
+	c.t.Fatalf("Lock(%q) called despite PreDownloadCheck reporting the file present", key)
+	return ""
+}
+func (c fakeCache) Unlock(key string) {
+	c.t.Fatalf("Unlock(%q) called despite PreDownloadCheck reporting the file present", key)
+}
+func (c fakeCache) RLock(key string) string {
+	c.t.Fatalf("RLock(%q) called despite PreDownloadCheck reporting the file present", key)
+	return ""
+}
+func (c fakeCache) RUnlock(key string) {
+	c.t.Fatalf("RUnlock(%q) called despite PreDownloadCheck reporting the file present", key)
+}
+
+// stubPreDownloadCheck reports the given url as already present at
+// remotePath, recording the cacheFilename it was called with.
+type stubPreDownloadCheck struct {
+	url, remotePath  string
+	gotCacheFilename string
+}
+
+func (s *stubPreDownloadCheck) Check(url, cacheFilename string) (string, bool, error) {
+	if url != s.url {
+		return "", false, nil
+	}
+	s.gotCacheFilename = cacheFilename
+	return s.remotePath, true, nil
+}
+
+func TestStepDownload_PreDownloadCheck(t *testing.T) {
+	const url = "http://example.com/foo.iso"
+	check := &stubPreDownloadCheck{url: url, remotePath: "[ds1] iso/foo.iso"}
+
+	step := &StepDownload{
+		Description:      "ISO",
+		ResultKey:        "iso_path",
+		Url:              []string{url},
+		PreDownloadCheck: check,
+	}
+
+	state := new(multistep.BasicStateBag)
+	state.Put("cache", fakeCache{t: t})
+	state.Put("ui", fakeUi{})
+
+	action := step.Run(state)
+	if action != multistep.ActionContinue {
+		t.Fatalf("Run() = %v, want ActionContinue", action)
+	}
+
+	if got, ok := state.GetOk("iso_path"); !ok || got != check.remotePath {
+		t.Errorf("state[%q] = %v, want %q", "iso_path", got, check.remotePath)
+	}
+
+	if want := cacheFilename(url, ""); check.gotCacheFilename != want {
+		t.Errorf("Check called with cacheFilename %q, want %q", check.gotCacheFilename, want)
+	}
+}